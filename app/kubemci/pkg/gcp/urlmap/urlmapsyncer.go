@@ -29,7 +29,9 @@ import (
 	"github.com/golang/glog"
 	multierror "github.com/hashicorp/go-multierror"
 	"k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ingresslb "k8s.io/ingress-gce/pkg/loadbalancers"
 	"k8s.io/ingress-gce/pkg/utils"
 
@@ -44,19 +46,129 @@ const (
 	hostRulePrefix = "host"
 )
 
+// Scope identifies whether a URL map is a global (external) resource or a
+// regional (internal) one. Internal multicluster load balancers use regional
+// URL maps, which live under a region and are reached through the
+// RegionUrlMaps GCE API instead of UrlMaps.
+type Scope string
+
+const (
+	// GlobalScope is used for the existing external, global load balancers.
+	GlobalScope Scope = "global"
+	// RegionalScope is used for internal load balancers, which are scoped to a region.
+	RegionalScope Scope = "regional"
+)
+
+// v2FinalizerPrefix marks a url map's description as not yet safe to delete.
+// In v2 naming mode, EnsureURLMap stamps this prefix (keyed to the owning
+// kube-system UID) onto the description alongside the existing status JSON,
+// and DeleteURLMap refuses to delete the url map until ClearFinalizer has
+// removed it.
+const v2FinalizerPrefix = "mci-finalizer:"
+
+// v2Finalizer returns the finalizer value this syncer stamps onto url maps it owns.
+func (s *Syncer) v2Finalizer() string {
+	return v2FinalizerPrefix + s.kubeSystemUID
+}
+
+// withFinalizer prepends this syncer's finalizer to the given description.
+func (s *Syncer) withFinalizer(desc string) string {
+	return s.v2Finalizer() + "\n" + desc
+}
+
+// hasFinalizer returns whether the given description still carries this syncer's finalizer.
+func (s *Syncer) hasFinalizer(desc string) bool {
+	return strings.HasPrefix(desc, s.v2Finalizer()+"\n")
+}
+
+// stripFinalizer removes this syncer's finalizer from the given description, if present.
+func (s *Syncer) stripFinalizer(desc string) string {
+	return strings.TrimPrefix(desc, s.v2Finalizer()+"\n")
+}
+
+// v2NamePrefix returns the deterministic name prefix used for url maps owned
+// by the given kube-system UID in v2 naming mode, mirroring the hashing
+// getNameForPathMatcher already uses for path matcher names.
+func v2NamePrefix(kubeSystemUID string) string {
+	hasher := md5.New()
+	hasher.Write([]byte(kubeSystemUID))
+	return fmt.Sprintf("mci2%v", hex.EncodeToString(hasher.Sum(nil))[:8])
+}
+
+// stripAnyFinalizer removes a leading finalizer marker line from desc, if
+// present, so the remainder can be handed to status.FromString.
+func stripAnyFinalizer(desc string) string {
+	if !strings.HasPrefix(desc, v2FinalizerPrefix) {
+		return desc
+	}
+	if i := strings.Index(desc, "\n"); i >= 0 {
+		return desc[i+1:]
+	}
+	return desc
+}
+
+// v2Name returns the deterministic, UID-anchored name for a url map owned by
+// the given kube-system UID. It still incorporates lbDerivedName (the name
+// namer.URLMapName would have produced) so that it stays unique per ingress,
+// while keeping v2NamePrefix(kubeSystemUID) as a genuine prefix of the
+// result, since ListLoadBalancerStatuses filters on exactly that prefix.
+func v2Name(kubeSystemUID, lbDerivedName string) string {
+	hasher := md5.New()
+	hasher.Write([]byte(lbDerivedName))
+	return v2NamePrefix(kubeSystemUID) + hex.EncodeToString(hasher.Sum(nil))[:8]
+}
+
+// urlMapName returns the name to use for this syncer's url map. In v2 naming
+// mode this must be the UID-anchored name rather than namer.URLMapName's
+// lbName-based one, since that's the name ListLoadBalancerStatuses's v2
+// prefix filter actually looks for.
+func (s *Syncer) urlMapName() string {
+	if s.enableV2Naming {
+		return v2Name(s.kubeSystemUID, s.namer.URLMapName())
+	}
+	return s.namer.URLMapName()
+}
+
 // Syncer manages GCP url maps for multicluster GCP L7 load balancers.
 type Syncer struct {
 	namer *utilsnamer.Namer
 	// Instance of URLMapProvider interface for calling GCE URLMap APIs.
 	// There is no separate URLMapProvider interface, so we use the bigger LoadBalancers interface here.
 	ump ingresslb.LoadBalancers
+	// scope determines whether this syncer manages a global or a regional url map.
+	scope Scope
+	// region is the GCP region to use for regional url map calls. In either
+	// scope, a non-empty region also makes ListLoadBalancerStatuses include
+	// the regional url maps of that region. Regional calls additionally
+	// require ump to implement regionalLoadBalancers; see regionalAPI.
+	region string
+	// enableV2Naming selects the v2 naming scheme, where resource names are
+	// derived from kubeSystemUID instead of the user-supplied lbName.
+	enableV2Naming bool
+	// kubeSystemUID is the UID of the kube-system namespace, fetched once at
+	// startup. Only used when enableV2Naming is set.
+	kubeSystemUID string
+	// defaultBackendName identifies the shared system default backend
+	// service to use for ingresses that don't declare their own, as
+	// configured via --default-backend-service. Empty when no default was
+	// configured, in which case ingToURLMap errors out as before.
+	// BackendServicesMap is keyed purely on service name (see
+	// getBackendService), so there is no namespace to thread through here.
+	defaultBackendName string
 }
 
-// NewURLMapSyncer returns a new instance of syncer.
-func NewURLMapSyncer(namer *utilsnamer.Namer, ump ingresslb.LoadBalancers) SyncerInterface {
+// NewURLMapSyncer returns a new instance of syncer. defaultBackendName comes
+// from the --default-backend-service flag and may be empty if the flag was
+// not set.
+func NewURLMapSyncer(namer *utilsnamer.Namer, ump ingresslb.LoadBalancers, scope Scope, region string, enableV2Naming bool, kubeSystemUID string, defaultBackendName string) SyncerInterface {
 	return &Syncer{
-		namer: namer,
-		ump:   ump,
+		namer:              namer,
+		ump:                ump,
+		scope:              scope,
+		region:             region,
+		enableV2Naming:     enableV2Naming,
+		kubeSystemUID:      kubeSystemUID,
+		defaultBackendName: defaultBackendName,
 	}
 }
 
@@ -65,7 +177,7 @@ var _ SyncerInterface = &Syncer{}
 
 // EnsureURLMap ensures that the required url map exists for the given ingress.
 // See the interface for more details.
-func (s *Syncer) EnsureURLMap(lbName, ipAddress string, clusters []string, ing *v1beta1.Ingress, beMap backendservice.BackendServicesMap, forceUpdate bool) (string, error) {
+func (s *Syncer) EnsureURLMap(lbName, ipAddress string, clusters []string, ing SyncableIngress, beMap backendservice.BackendServicesMap, forceUpdate bool) (string, error) {
 	fmt.Println("Ensuring url map")
 	var err error
 	desiredUM, err := s.desiredURLMap(lbName, ipAddress, clusters, ing, beMap)
@@ -74,18 +186,14 @@ func (s *Syncer) EnsureURLMap(lbName, ipAddress string, clusters []string, ing *
 	}
 	name := desiredUM.Name
 	// Check if url map already exists.
-	existingUM, err := s.ump.GetUrlMap(name)
+	existingUM, err := s.getUrlMap(name)
 	if err == nil {
 		fmt.Println("url map", name, "exists already. Checking if it matches our desired url map", name)
 		glog.V(5).Infof("Existing url map: %v\n, desired url map: %v", existingUM, desiredUM)
-		// Fingerprint is required (and we get an error if it's not set).
-		// TODO(G-Harmon): Figure out how to properly calculate the
-		// FP. Using Sha256 returned a googleapi error. We shouldn't use
-		// the existing FP when we're changing the object (as it seems
-		// like it's used for some oportunistic optimization on the
-		// server side).
-		desiredUM.Fingerprint = existingUM.Fingerprint
 		// URL Map with that name exists already. Check if it matches what we want.
+		// urlMapMatches ignores Fingerprint, since the one we hold here is
+		// about to go stale the moment we (or anyone else) write to the
+		// resource; updateURLMap fetches a fresh one right before patching.
 		if urlMapMatches(*desiredUM, *existingUM) {
 			// Nothing to do. Desired url map exists already.
 			fmt.Println("Desired url map exists already")
@@ -104,11 +212,28 @@ func (s *Syncer) EnsureURLMap(lbName, ipAddress string, clusters []string, ing *
 }
 
 // DeleteURLMap deletes the url map that EnsureURLMap would have created.
+// In v2 naming mode, the url map is only deleted once its finalizer has been
+// cleared by a separate, caller-invoked ClearFinalizer call; until then it
+// leaves the url map in place and returns an error, so that other in-flight
+// kubemci operations relying on the resource are not disrupted.
 // See the interface for more details.
 func (s *Syncer) DeleteURLMap() error {
-	name := s.namer.URLMapName()
+	name := s.urlMapName()
+	if s.enableV2Naming {
+		existingUM, err := s.getUrlMap(name)
+		if err != nil {
+			if utils.IsHTTPErrorCode(err, http.StatusNotFound) {
+				fmt.Println("URL map", name, "does not exist. Nothing to delete")
+				return nil
+			}
+			return err
+		}
+		if s.hasFinalizer(existingUM.Description) {
+			return fmt.Errorf("url map %s still has finalizer %s set; call ClearFinalizer before deleting", name, s.v2Finalizer())
+		}
+	}
 	fmt.Println("Deleting url map", name)
-	err := s.ump.DeleteUrlMap(name)
+	err := s.deleteUrlMap(name)
 	if err != nil {
 		if utils.IsHTTPErrorCode(err, http.StatusNotFound) {
 			fmt.Println("URL map", name, "does not exist. Nothing to delete")
@@ -121,12 +246,35 @@ func (s *Syncer) DeleteURLMap() error {
 	return nil
 }
 
+// ClearFinalizer removes this syncer's finalizer from the url map's
+// description, allowing a subsequent DeleteURLMap call to actually remove it.
+// It is a no-op when v2 naming is disabled or the finalizer is already gone.
+func (s *Syncer) ClearFinalizer() error {
+	if !s.enableV2Naming {
+		return nil
+	}
+	name := s.urlMapName()
+	existingUM, err := s.getUrlMap(name)
+	if err != nil {
+		if utils.IsHTTPErrorCode(err, http.StatusNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error in fetching url map %s to clear finalizer: %s", name, err)
+	}
+	if !s.hasFinalizer(existingUM.Description) {
+		return nil
+	}
+	existingUM.Description = s.stripFinalizer(existingUM.Description)
+	_, err = s.updateURLMap(existingUM)
+	return err
+}
+
 // GetLoadBalancerStatus returns the status of the given load balancer if it is stored on the url map.
 // See the interface for more details.
 func (s *Syncer) GetLoadBalancerStatus(lbName string) (*status.LoadBalancerStatus, error) {
 	// Fetch the url map.
-	name := s.namer.URLMapName()
-	um, err := s.ump.GetUrlMap(name)
+	name := s.urlMapName()
+	um, err := s.getUrlMap(name)
 	if err == nil {
 		return getStatus(um)
 	}
@@ -138,7 +286,7 @@ func (s *Syncer) GetLoadBalancerStatus(lbName string) (*status.LoadBalancerStatu
 }
 
 func getStatus(um *compute.UrlMap) (*status.LoadBalancerStatus, error) {
-	status, err := status.FromString(um.Description)
+	status, err := status.FromString(stripAnyFinalizer(um.Description))
 	if err != nil {
 		return nil, fmt.Errorf("error in parsing url map description %s. Cannot determine status without it", err)
 	}
@@ -147,21 +295,55 @@ func getStatus(um *compute.UrlMap) (*status.LoadBalancerStatus, error) {
 
 // ListLoadBalancerStatuses returns a list of load balancer status from load balancers that have the status stored on their url maps.
 // It ignores the load balancers that dont have status on their url map.
-// Returns an error if listing url maps fails.
+// Returns an error if listing the global url maps fails. If only the
+// regional scope fails (e.g. the region doesn't support it, or the API call
+// itself errors), the global results are still returned alongside a non-nil
+// error describing the regional failure, so callers can surface it instead
+// of silently returning an incomplete list.
 // See the interface for more details.
 func (s *Syncer) ListLoadBalancerStatuses() ([]status.LoadBalancerStatus, error) {
-	var maps []*compute.UrlMap
-	var err error
 	result := []status.LoadBalancerStatus{}
-	if maps, err = s.ump.ListUrlMaps(); err != nil {
-		err = fmt.Errorf("Error getting url maps: %s", err)
+	globalMaps, err := s.ump.ListUrlMaps()
+	if err != nil {
+		err = fmt.Errorf("Error getting global url maps: %s", err)
 		glog.V(2).Infof("%s\n", err)
 		return result, err
 	}
+	// Regional (internal) load balancers live alongside the global ones, so
+	// we merge both scopes here to give a complete picture to `kubemci list`.
+	// An empty region means this syncer was never configured for regional
+	// resources (e.g. an existing global-only deployment), so skip the
+	// regional call entirely rather than querying the API with no region.
+	maps := globalMaps
+	var regionalErr error
+	if s.region != "" {
+		if rlb, rerr := s.regionalAPI(); rerr != nil {
+			regionalErr = rerr
+		} else if regionalMaps, listErr := rlb.ListRegionUrlMaps(s.region); listErr != nil {
+			regionalErr = fmt.Errorf("error getting regional url maps in region %s: %s", s.region, listErr)
+		} else {
+			maps = append(maps, regionalMaps...)
+		}
+	}
+	if regionalErr != nil {
+		// Don't fail the whole list because the regional scope had a
+		// problem, but don't hide it either: a silently incomplete list is
+		// worse than a warning the user can act on, especially since this is
+		// exactly the case where internal load balancers are unhealthy.
+		fmt.Println("Warning:", regionalErr, "- list will only include global load balancers")
+	}
 	glog.V(5).Infof("maps: %+v", maps)
+	// v2 naming replaces the brittle "mci1" name-prefix check with a check
+	// against the UID-derived prefix this instance's own url maps carry,
+	// which reliably identifies MCI-owned resources even across projects
+	// that share a kube-system UID namespace.
+	namePrefix := "mci1"
+	if s.enableV2Naming {
+		namePrefix = v2NamePrefix(s.kubeSystemUID)
+	}
 	for _, item := range maps {
-		if strings.HasPrefix(item.Name, "mci1") {
-			lbStatus, decodeErr := status.FromString(item.Description)
+		if strings.HasPrefix(item.Name, namePrefix) {
+			lbStatus, decodeErr := status.FromString(stripAnyFinalizer(item.Description))
 			if decodeErr != nil {
 				// Assume that forwarding rule has the right status for this MCI.
 				glog.V(3).Infof("Error decoding load balancer status on url map %s: %s\nAssuming status is stored on forwarding rule. Ignoring the error and continuing.", item.Name, decodeErr)
@@ -170,15 +352,15 @@ func (s *Syncer) ListLoadBalancerStatuses() ([]status.LoadBalancerStatus, error)
 			result = append(result, *lbStatus)
 		}
 	}
-	return result, nil
+	return result, regionalErr
 }
 
 // RemoveClustersFromStatus removes the given clusters from the LoadBalancerStatus.
 // See the interface for more details.
 func (s *Syncer) RemoveClustersFromStatus(clusters []string) error {
 	fmt.Println("Removing clusters", clusters, "from url map")
-	name := s.namer.URLMapName()
-	existingUM, err := s.ump.GetUrlMap(name)
+	name := s.urlMapName()
+	existingUM, err := s.getUrlMap(name)
 	if err != nil {
 		if utils.IsHTTPErrorCode(err, http.StatusNotFound) {
 			// Load balancer does not exist.
@@ -200,44 +382,172 @@ func (s *Syncer) RemoveClustersFromStatus(clusters []string) error {
 	return err
 }
 
+// maxFingerprintConflictRetries bounds the total number of GET-then-patch
+// attempts updateURLMap makes, the first one included, before giving up on
+// repeated Fingerprint-mismatch conflicts.
+const maxFingerprintConflictRetries = 3
+
+// updateURLMap patches the existing url map to match the fields of desiredUM
+// that we own (HostRules, PathMatchers, Description, DefaultService). It
+// always re-reads the url map immediately before patching so it sends the
+// server's current Fingerprint, and retries on a 412 Fingerprint-mismatch
+// conflict, which can happen if another kubemci invocation is racing us.
 func (s *Syncer) updateURLMap(desiredUM *compute.UrlMap) (string, error) {
 	name := desiredUM.Name
 	fmt.Println("Updating existing url map", name, "to match the desired state")
-	err := s.ump.UpdateUrlMap(desiredUM)
-	if err != nil {
-		return "", err
+	var lastErr error
+	for attempt := 1; attempt <= maxFingerprintConflictRetries; attempt++ {
+		existingUM, err := s.getUrlMap(name)
+		if err != nil {
+			return "", err
+		}
+		patchUM := urlMapPatch(existingUM, desiredUM)
+		glog.V(2).Infof("Patching url map %s (attempt %d/%d):\n%v", name, attempt, maxFingerprintConflictRetries, diff.ObjectDiff(existingUM, patchUM))
+		lastErr = s.patchUrlMap(patchUM)
+		if lastErr == nil {
+			break
+		}
+		if !utils.IsHTTPErrorCode(lastErr, http.StatusPreconditionFailed) {
+			return "", lastErr
+		}
+		if attempt < maxFingerprintConflictRetries {
+			fmt.Println("Fingerprint conflict updating url map", name, "- re-fetching and retrying")
+		}
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("giving up updating url map %s after %d attempts due to repeated fingerprint conflicts: %s", name, maxFingerprintConflictRetries, lastErr)
 	}
 	fmt.Println("URL Map", name, "updated successfully")
-	um, err := s.ump.GetUrlMap(name)
+	um, err := s.getUrlMap(name)
 	if err != nil {
 		return "", err
 	}
 	return um.SelfLink, nil
 }
 
+// urlMapPatch builds the url map to send as a patch: a copy of existingUM,
+// including its current Fingerprint, with only the fields we manage taken
+// from desiredUM.
+func urlMapPatch(existingUM, desiredUM *compute.UrlMap) *compute.UrlMap {
+	patchUM := *existingUM
+	patchUM.Description = desiredUM.Description
+	patchUM.DefaultService = desiredUM.DefaultService
+	patchUM.HostRules = desiredUM.HostRules
+	patchUM.PathMatchers = desiredUM.PathMatchers
+	return &patchUM
+}
+
 func (s *Syncer) createURLMap(desiredUM *compute.UrlMap) (string, error) {
 	name := desiredUM.Name
 	fmt.Println("Creating url map", name)
 	glog.V(5).Infof("Creating url map %v", desiredUM)
-	err := s.ump.CreateUrlMap(desiredUM)
-	if err != nil {
+	if err := s.insertUrlMap(desiredUM); err != nil {
 		return "", err
 	}
 	fmt.Println("URL Map", name, "created successfully")
-	um, err := s.ump.GetUrlMap(name)
+	um, err := s.getUrlMap(name)
 	if err != nil {
 		return "", err
 	}
 	return um.SelfLink, nil
 }
 
+// regionalLoadBalancers is the regional (internal load balancer) counterpart
+// of ingresslb.LoadBalancers' url map methods. It is deliberately kept as a
+// separate, optional interface rather than folded into ingresslb.LoadBalancers
+// (which this package doesn't own and isn't guaranteed to implement these for
+// every backend): callers must type-assert s.ump against it and handle the
+// "not supported" case, instead of depending on these methods unconditionally.
+type regionalLoadBalancers interface {
+	GetRegionUrlMap(name, region string) (*compute.UrlMap, error)
+	CreateRegionUrlMap(um *compute.UrlMap, region string) error
+	UpdateRegionUrlMap(um *compute.UrlMap, region string) error
+	DeleteRegionUrlMap(name, region string) error
+	ListRegionUrlMaps(region string) ([]*compute.UrlMap, error)
+}
+
+// regionalAPI returns s.ump as a regionalLoadBalancers, or an error if the
+// configured backend doesn't support regional url maps.
+func (s *Syncer) regionalAPI() (regionalLoadBalancers, error) {
+	rlb, ok := s.ump.(regionalLoadBalancers)
+	if !ok {
+		return nil, fmt.Errorf("region %q was requested but the configured LoadBalancers backend (%T) does not support regional url maps", s.region, s.ump)
+	}
+	return rlb, nil
+}
+
+// getUrlMap fetches the url map with the given name, calling the regional or
+// global API depending on the scope this syncer was constructed with.
+func (s *Syncer) getUrlMap(name string) (*compute.UrlMap, error) {
+	if s.scope == RegionalScope {
+		rlb, err := s.regionalAPI()
+		if err != nil {
+			return nil, err
+		}
+		return rlb.GetRegionUrlMap(name, s.region)
+	}
+	return s.ump.GetUrlMap(name)
+}
+
+// insertUrlMap creates the given url map, calling the regional or global API
+// depending on the scope this syncer was constructed with.
+func (s *Syncer) insertUrlMap(um *compute.UrlMap) error {
+	if s.scope == RegionalScope {
+		rlb, err := s.regionalAPI()
+		if err != nil {
+			return err
+		}
+		return rlb.CreateRegionUrlMap(um, s.region)
+	}
+	return s.ump.CreateUrlMap(um)
+}
+
+// patchUrlMap updates the given url map, calling the regional or global API
+// depending on the scope this syncer was constructed with. um is expected to
+// already carry the server's current Fingerprint and only the fields we
+// manage changed, so this behaves like a patch even though the underlying
+// call is a full Update.
+func (s *Syncer) patchUrlMap(um *compute.UrlMap) error {
+	if s.scope == RegionalScope {
+		rlb, err := s.regionalAPI()
+		if err != nil {
+			return err
+		}
+		return rlb.UpdateRegionUrlMap(um, s.region)
+	}
+	return s.ump.UpdateUrlMap(um)
+}
+
+// deleteUrlMap deletes the url map with the given name, calling the regional
+// or global API depending on the scope this syncer was constructed with.
+func (s *Syncer) deleteUrlMap(name string) error {
+	if s.scope == RegionalScope {
+		rlb, err := s.regionalAPI()
+		if err != nil {
+			return err
+		}
+		return rlb.DeleteRegionUrlMap(name, s.region)
+	}
+	return s.ump.DeleteUrlMap(name)
+}
+
 func urlMapMatches(desiredUM, existingUM compute.UrlMap) bool {
-	// Clear output-only fields to do our comparison
+	// Clear output-only fields to do our comparison. Fingerprint changes on
+	// every write, including ones we made ourselves, so it is never part of
+	// what we consider our desired state.
 	existingUM.CreationTimestamp = ""
 	existingUM.Kind = ""
 	existingUM.Id = 0
 	existingUM.SelfLink = ""
 	existingUM.ServerResponse = googleapi.ServerResponse{}
+	existingUM.Fingerprint = ""
+	desiredUM.Fingerprint = ""
+
+	// HostRules, PathMatchers and PathRules are built by ranging over maps,
+	// so their order is not stable across calls. Sort both sides the same
+	// way before comparing so that doesn't cause spurious mismatches.
+	sortURLMapForComparison(&desiredUM)
+	sortURLMapForComparison(&existingUM)
 
 	glog.V(5).Infof("desired UM:\n%+v", desiredUM)
 	glog.V(5).Infof("existing UM:\n%+v", existingUM)
@@ -249,15 +559,34 @@ func urlMapMatches(desiredUM, existingUM compute.UrlMap) bool {
 	return equal
 }
 
-func (s *Syncer) desiredURLMap(lbName, ipAddress string, clusters []string, ing *v1beta1.Ingress, beMap backendservice.BackendServicesMap) (*compute.UrlMap, error) {
+// sortURLMapForComparison sorts a url map's HostRules, PathMatchers, and each
+// PathMatcher's PathRules into a deterministic order.
+func sortURLMapForComparison(um *compute.UrlMap) {
+	sort.Slice(um.HostRules, func(i, j int) bool {
+		return strings.Join(um.HostRules[i].Hosts, ",") < strings.Join(um.HostRules[j].Hosts, ",")
+	})
+	sort.Slice(um.PathMatchers, func(i, j int) bool {
+		return um.PathMatchers[i].Name < um.PathMatchers[j].Name
+	})
+	for _, pm := range um.PathMatchers {
+		sort.Slice(pm.PathRules, func(i, j int) bool {
+			return strings.Join(pm.PathRules[i].Paths, ",") < strings.Join(pm.PathRules[j].Paths, ",")
+		})
+	}
+}
+
+func (s *Syncer) desiredURLMap(lbName, ipAddress string, clusters []string, ing SyncableIngress, beMap backendservice.BackendServicesMap) (*compute.UrlMap, error) {
 	desc, err := desiredStatusString(lbName, "URL map", ipAddress, clusters)
 	if err != nil {
 		return nil, err
 	}
+	if s.enableV2Naming {
+		desc = s.withFinalizer(desc)
+	}
 
 	// Compute the desired url map.
 	um := &compute.UrlMap{
-		Name:        s.namer.URLMapName(),
+		Name:        s.urlMapName(),
 		Description: desc,
 	}
 	gceMap, err := s.ingToURLMap(ing, beMap)
@@ -272,32 +601,67 @@ func (s *Syncer) desiredURLMap(lbName, ipAddress string, clusters []string, ing
 		um.HostRules = []*compute.HostRule{}
 		um.PathMatchers = []*compute.PathMatcher{}
 	}
+	hostRules, pathMatchers := urlMapRulesFor(gceMap, um.DefaultService)
+	um.HostRules = append(um.HostRules, hostRules...)
+	um.PathMatchers = append(um.PathMatchers, pathMatchers...)
+	return um, nil
+}
 
-	// Code taken from kubernetes/ingress-gce/L7s.UpdateUrlMap.
-	// TODO: Refactor it to share code.
+// urlMapRulesFor builds the HostRules and PathMatchers for gceMap, sharing
+// one PathMatcher across all hosts that route to the same path-to-backend
+// map instead of creating a PathMatcher per host. This keeps the url map
+// small for ingresses where many hosts serve the same set of paths.
+//
+// Note this is "one PathMatcher per distinct path-to-backend map", not "one
+// PathMatcher per distinct backend" with a host referencing several of them:
+// compute.HostRule.PathMatcher is a single string, so a host can only ever
+// point at exactly one PathMatcher.
+func urlMapRulesFor(gceMap utils.GCEURLMap, defaultService string) ([]*compute.HostRule, []*compute.PathMatcher) {
+	var hostRules []*compute.HostRule
+	var pathMatchers []*compute.PathMatcher
+	pmNames := map[string]string{}
 	for hostname, urlToBackend := range gceMap {
-		// Create a host rule
-		// Create a path matcher
-		// Add all given endpoint:backends to pathRules in path matcher
-		pmName := getNameForPathMatcher(hostname)
-		um.HostRules = append(um.HostRules, &compute.HostRule{
+		pmKey := pathMapKey(urlToBackend)
+		pmName, exists := pmNames[pmKey]
+		if !exists {
+			pmName = getNameForPathMatcher(pmKey)
+			pmNames[pmKey] = pmName
+			pathMatcher := &compute.PathMatcher{
+				Name:           pmName,
+				DefaultService: defaultService,
+				PathRules:      []*compute.PathRule{},
+			}
+			for expr, be := range urlToBackend {
+				pathMatcher.PathRules = append(
+					pathMatcher.PathRules, &compute.PathRule{Paths: []string{expr}, Service: be.SelfLink})
+			}
+			pathMatchers = append(pathMatchers, pathMatcher)
+		}
+		hostRules = append(hostRules, &compute.HostRule{
 			Hosts:       []string{hostname},
 			PathMatcher: pmName,
 		})
+	}
+	return hostRules, pathMatchers
+}
 
-		pathMatcher := &compute.PathMatcher{
-			Name:           pmName,
-			DefaultService: um.DefaultService,
-			PathRules:      []*compute.PathRule{},
-		}
-
-		for expr, be := range urlToBackend {
-			pathMatcher.PathRules = append(
-				pathMatcher.PathRules, &compute.PathRule{Paths: []string{expr}, Service: be.SelfLink})
-		}
-		um.PathMatchers = append(um.PathMatchers, pathMatcher)
+// pathMapKey returns a deterministic string representation of a host's
+// path-to-backend map, used to detect which hosts route identically so they
+// can share a single PathMatcher.
+func pathMapKey(urlToBackend map[string]*compute.BackendService) string {
+	exprs := make([]string, 0, len(urlToBackend))
+	for expr := range urlToBackend {
+		exprs = append(exprs, expr)
 	}
-	return um, nil
+	sort.Strings(exprs)
+	var key strings.Builder
+	for _, expr := range exprs {
+		key.WriteString(expr)
+		key.WriteString("=>")
+		key.WriteString(urlToBackend[expr].SelfLink)
+		key.WriteString("|")
+	}
+	return key.String()
 }
 
 // desiredStatusString returns the expected LoadBalancerStatus converted to string that can be stored as description based on the given input parameters.
@@ -319,67 +683,248 @@ func desiredStatusString(lbName, resourceName, ipAddress string, clusters []stri
 
 // desiredURLMapWithoutClusters returns a desired url map based on the given existing map such that the given list of clusters is removed from the status.
 func (s *Syncer) desiredURLMapWithoutClusters(existingUM *compute.UrlMap, clustersToRemove []string) (*compute.UrlMap, error) {
-	existingStatusStr := existingUM.Description
+	hadFinalizer := s.enableV2Naming && s.hasFinalizer(existingUM.Description)
+	existingStatusStr := stripAnyFinalizer(existingUM.Description)
 	newStatusStr, err := status.RemoveClusters(existingStatusStr, clustersToRemove)
 	if err != nil {
 		return nil, fmt.Errorf("unexpected error in updating status to remove clusters on url map %s: %s", existingUM.Name, err)
 	}
+	if hadFinalizer {
+		newStatusStr = s.withFinalizer(newStatusStr)
+	}
 	// Shallow copy is fine since we are only changing description.
 	desiredUM := existingUM
 	desiredUM.Description = newStatusStr
 	return desiredUM, nil
 }
 
+// SyncableIngress abstracts over the Ingress API versions kubemci can build a
+// url map from. Use FromV1Beta1Ingress or FromNetworkingV1Ingress to wrap a
+// concrete Ingress object.
+type SyncableIngress interface {
+	namespace() string
+	rules() []commonIngressRule
+	defaultBackend() *v1beta1.IngressBackend
+}
+
+// commonIngressRule is a single host's rules, independent of Ingress API version.
+type commonIngressRule struct {
+	host  string
+	paths []commonIngressPath
+}
+
+// commonIngressPath is a single path rule, independent of Ingress API version.
+type commonIngressPath struct {
+	path     string
+	pathType networkingv1.PathType
+	backend  *v1beta1.IngressBackend
+}
+
+// FromV1Beta1Ingress wraps an extensions/v1beta1 Ingress as a SyncableIngress.
+// Every path is treated as ImplementationSpecific, matching this API
+// version's historical (pre-PathType) behavior.
+func FromV1Beta1Ingress(ing *v1beta1.Ingress) SyncableIngress {
+	return v1beta1Ingress{ing}
+}
+
+type v1beta1Ingress struct {
+	ing *v1beta1.Ingress
+}
+
+func (a v1beta1Ingress) namespace() string { return a.ing.Namespace }
+
+func (a v1beta1Ingress) defaultBackend() *v1beta1.IngressBackend { return a.ing.Spec.Backend }
+
+func (a v1beta1Ingress) rules() []commonIngressRule {
+	var out []commonIngressRule
+	for _, rule := range a.ing.Spec.Rules {
+		if rule.HTTP == nil {
+			fmt.Println("Ignoring non http ingress rule", rule)
+			continue
+		}
+		cr := commonIngressRule{host: rule.Host}
+		for _, p := range rule.HTTP.Paths {
+			backend := p.Backend
+			cr.paths = append(cr.paths, commonIngressPath{
+				path:     p.Path,
+				pathType: networkingv1.PathTypeImplementationSpecific,
+				backend:  &backend,
+			})
+		}
+		out = append(out, cr)
+	}
+	return out
+}
+
+// FromNetworkingV1Ingress wraps a networking.k8s.io/v1 Ingress as a SyncableIngress.
+func FromNetworkingV1Ingress(ing *networkingv1.Ingress) SyncableIngress {
+	return networkingV1Ingress{ing}
+}
+
+type networkingV1Ingress struct {
+	ing *networkingv1.Ingress
+}
+
+func (a networkingV1Ingress) namespace() string { return a.ing.Namespace }
+
+func (a networkingV1Ingress) defaultBackend() *v1beta1.IngressBackend {
+	return toV1Beta1Backend(a.ing.Spec.DefaultBackend)
+}
+
+func (a networkingV1Ingress) rules() []commonIngressRule {
+	var out []commonIngressRule
+	for _, rule := range a.ing.Spec.Rules {
+		if rule.HTTP == nil {
+			fmt.Println("Ignoring non http ingress rule", rule)
+			continue
+		}
+		cr := commonIngressRule{host: rule.Host}
+		for _, p := range rule.HTTP.Paths {
+			pathType := networkingv1.PathTypeImplementationSpecific
+			if p.PathType != nil {
+				pathType = *p.PathType
+			}
+			cr.paths = append(cr.paths, commonIngressPath{
+				path:     p.Path,
+				pathType: pathType,
+				backend:  toV1Beta1Backend(&p.Backend),
+			})
+		}
+		out = append(out, cr)
+	}
+	return out
+}
+
+// toV1Beta1Backend converts a networking.k8s.io/v1 IngressBackend to the
+// extensions/v1beta1.IngressBackend shape the rest of this package (and
+// backendservice.BackendServicesMap) is keyed on.
+func toV1Beta1Backend(be *networkingv1.IngressBackend) *v1beta1.IngressBackend {
+	if be == nil || be.Service == nil {
+		return nil
+	}
+	return &v1beta1.IngressBackend{
+		ServiceName: be.Service.Name,
+		ServicePort: intstr.FromInt(int(be.Service.Port.Number)),
+	}
+}
+
+// systemDefaultBackend returns the IngressBackend for the shared system
+// default backend service configured via --default-backend-service, or nil
+// if the flag wasn't set. This only points at the service by name;
+// urlmap.Syncer never creates backend services itself (the same is true for
+// ones declared on the ingress) - that's done by backendservice.Syncer
+// against the BackendServicesMap passed into EnsureURLMap.
+//
+// Unlike ingress-declared backends, this one is NOT currently part of any GC
+// path: backendservice.Syncer's ensure/GC set is built from the ingress spec
+// (Spec.Backend and Spec.Rules[].Backend), and --default-backend-service is
+// never referenced there, so nothing discovers or cleans it up. No commit in
+// this series adds it to that set. Until backendservice.Syncer is extended to
+// special-case this flag, a configured default backend service is never
+// garbage collected, and if it isn't already present in beMap,
+// getBackendService below fails with an actionable error rather than
+// silently dropping the default route.
+func (s *Syncer) systemDefaultBackend() *v1beta1.IngressBackend {
+	if s.defaultBackendName == "" {
+		return nil
+	}
+	return &v1beta1.IngressBackend{
+		ServiceName: s.defaultBackendName,
+		ServicePort: intstr.FromInt(80),
+	}
+}
+
+// pathExpressionsForType expands a single Ingress path into the GCE path
+// expression(s) it should match, based on its PathType:
+//   - Exact matches the literal path only.
+//   - Prefix matches the path itself plus everything under it (path and path/*).
+//   - ImplementationSpecific (or an unset path type) is passed through as-is,
+//     except that an empty path becomes the catch-all ingresslb.DefaultPath.
+func pathExpressionsForType(path string, pathType networkingv1.PathType) ([]string, error) {
+	switch pathType {
+	case networkingv1.PathTypeExact:
+		if path == "" {
+			return nil, fmt.Errorf("path type Exact requires a non-empty path")
+		}
+		return []string{path}, nil
+	case networkingv1.PathTypePrefix:
+		prefix := path
+		if prefix == "" {
+			prefix = ingresslb.DefaultPath
+		}
+		if prefix == "/" {
+			return []string{"/*"}, nil
+		}
+		prefix = strings.TrimSuffix(prefix, "/")
+		return []string{prefix, prefix + "/*"}, nil
+	case networkingv1.PathTypeImplementationSpecific, "":
+		// The Ingress spec defines empty path as catch-all, so if a user
+		// asks for a single host and multiple empty paths, all traffic is
+		// sent to one of the last backend in the rules list.
+		if path == "" {
+			path = ingresslb.DefaultPath
+		}
+		return []string{path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported path type %q", pathType)
+	}
+}
+
 // ingToURLMap converts an ingress to GCEURLMap (nested map of subdomain: url-regex: gce backend).
 // TODO: Copied from kubernetes/ingress-gce with minor changes to print errors
 // instead of generating events. Refactor it to make it reusable.
-func (s *Syncer) ingToURLMap(ing *v1beta1.Ingress, beMap backendservice.BackendServicesMap) (utils.GCEURLMap, error) {
+func (s *Syncer) ingToURLMap(ing SyncableIngress, beMap backendservice.BackendServicesMap) (utils.GCEURLMap, error) {
 	hostPathBackend := utils.GCEURLMap{}
 	var err error
-	for _, rule := range ing.Spec.Rules {
-		if rule.HTTP == nil {
-			fmt.Println("Ignoring non http ingress rule", rule)
-			continue
-		}
+	for _, rule := range ing.rules() {
 		pathToBackend := map[string]*compute.BackendService{}
-		for _, p := range rule.HTTP.Paths {
-			backend, beErr := getBackendService(&p.Backend, ing.Namespace, beMap)
+		pathTypes := map[string]networkingv1.PathType{}
+		for _, p := range rule.paths {
+			backend, beErr := getBackendService(p.backend, ing.namespace(), beMap)
 			if beErr != nil {
-				fmt.Println("Skipping path", p.Backend, "due to error", beErr)
+				fmt.Println("Skipping path", p.path, "due to error", beErr)
 				err = multierror.Append(err, beErr)
 				continue
 			}
-			// The Ingress spec defines empty path as catch-all, so if a user
-			// asks for a single host and multiple empty paths, all traffic is
-			// sent to one of the last backend in the rules list.
-			path := p.Path
-			if path == "" {
-				path = ingresslb.DefaultPath
+			exprs, exprErr := pathExpressionsForType(p.path, p.pathType)
+			if exprErr != nil {
+				err = multierror.Append(err, fmt.Errorf("skipping path %q on host %q: %s", p.path, rule.host, exprErr))
+				continue
+			}
+			for _, expr := range exprs {
+				if seenType, ok := pathTypes[expr]; ok && seenType != p.pathType {
+					err = multierror.Append(err, fmt.Errorf("conflicting path types %q and %q for path %q on host %q", seenType, p.pathType, expr, rule.host))
+					continue
+				}
+				pathTypes[expr] = p.pathType
+				pathToBackend[expr] = backend
 			}
-			pathToBackend[path] = backend
 		}
 		// If multiple hostless rule sets are specified, last one wins
-		host := rule.Host
+		host := rule.host
 		if host == "" {
 			host = ingresslb.DefaultHost
 		}
 		hostPathBackend[host] = pathToBackend
 	}
-	var defaultBackend *compute.BackendService
-	if ing.Spec.Backend == nil {
-		// TODO(nikhiljindal): Be able to create a default backend service.
-		// For now, we require users to specify it and generate an error if it's nil.
-		// We can't create a url map without a default service, so no point continuing.
-		err = multierror.Append(err, fmt.Errorf("unexpected: ing.spec.backend is nil. Multicluster ingress needs a user specified default backend"))
+	defaultBackend := ing.defaultBackend()
+	if defaultBackend == nil {
+		defaultBackend = s.systemDefaultBackend()
+	}
+	if defaultBackend == nil {
+		err = multierror.Append(err, fmt.Errorf("unexpected: ing.spec.backend is nil and no --default-backend-service is configured. Multicluster ingress needs a default backend"))
 		return nil, err
 	}
-	defaultBackend, beErr := getBackendService(ing.Spec.Backend, ing.Namespace, beMap)
+	defaultBackendService, beErr := getBackendService(defaultBackend, ing.namespace(), beMap)
 	if beErr != nil {
-		fmt.Printf("Error getting backend service %s: %v", ing.Spec.Backend.ServiceName, beErr)
+		if ing.defaultBackend() == nil {
+			beErr = fmt.Errorf("--default-backend-service %q not found in beMap: it must already be ensured by backendservice.Syncer, since nothing in this package creates it and it is not currently part of any GC set (%s)", defaultBackend.ServiceName, beErr)
+		}
+		fmt.Printf("Error getting backend service %s: %v", defaultBackend.ServiceName, beErr)
 		err = multierror.Append(err, beErr)
 		return nil, err
 	}
-	hostPathBackend.PutDefaultBackend(defaultBackend)
+	hostPathBackend.PutDefaultBackend(defaultBackendService)
 	return hostPathBackend, err
 }
 
@@ -0,0 +1,270 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urlmap
+
+import (
+	"testing"
+
+	compute "google.golang.org/api/compute/v1"
+	"k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/ingress-gce/pkg/utils"
+
+	"github.com/GoogleCloudPlatform/k8s-multicluster-ingress/app/kubemci/pkg/gcp/backendservice"
+)
+
+// fakeIngress is a minimal SyncableIngress test double.
+type fakeIngress struct {
+	ns    string
+	rs    []commonIngressRule
+	defBE *v1beta1.IngressBackend
+}
+
+func (f fakeIngress) namespace() string                       { return f.ns }
+func (f fakeIngress) rules() []commonIngressRule               { return f.rs }
+func (f fakeIngress) defaultBackend() *v1beta1.IngressBackend  { return f.defBE }
+
+func pathMatcherFor(t *testing.T, pathMatchers []*compute.PathMatcher, name string) *compute.PathMatcher {
+	t.Helper()
+	for _, pm := range pathMatchers {
+		if pm.Name == name {
+			return pm
+		}
+	}
+	t.Fatalf("no PathMatcher named %q in %+v", name, pathMatchers)
+	return nil
+}
+
+func hostRuleFor(t *testing.T, hostRules []*compute.HostRule, host string) *compute.HostRule {
+	t.Helper()
+	for _, hr := range hostRules {
+		for _, h := range hr.Hosts {
+			if h == host {
+				return hr
+			}
+		}
+	}
+	t.Fatalf("no HostRule for host %q in %+v", host, hostRules)
+	return nil
+}
+
+func TestUrlMapRulesForSharesPathMatcherAcrossIdenticalHosts(t *testing.T) {
+	be := &compute.BackendService{SelfLink: "backend-foo"}
+	gceMap := utils.GCEURLMap{
+		"a.com": {"/foo": be},
+		"b.com": {"/foo": be},
+	}
+
+	hostRules, pathMatchers := urlMapRulesFor(gceMap, "default-backend")
+
+	if len(pathMatchers) != 1 {
+		t.Fatalf("got %d path matchers, want 1 shared one: %+v", len(pathMatchers), pathMatchers)
+	}
+	if len(hostRules) != 2 {
+		t.Fatalf("got %d host rules, want 2: %+v", len(hostRules), hostRules)
+	}
+	aRule := hostRuleFor(t, hostRules, "a.com")
+	bRule := hostRuleFor(t, hostRules, "b.com")
+	if aRule.PathMatcher != bRule.PathMatcher {
+		t.Fatalf("expected a.com and b.com to share a PathMatcher, got %q and %q", aRule.PathMatcher, bRule.PathMatcher)
+	}
+}
+
+func TestUrlMapRulesForSplitsOverlappingButDifferentHosts(t *testing.T) {
+	be1 := &compute.BackendService{SelfLink: "backend-foo"}
+	be2 := &compute.BackendService{SelfLink: "backend-bar"}
+	gceMap := utils.GCEURLMap{
+		"a.com": {"/foo": be1},
+		"b.com": {"/foo": be1, "/bar": be2},
+	}
+
+	hostRules, pathMatchers := urlMapRulesFor(gceMap, "default-backend")
+
+	if len(pathMatchers) != 2 {
+		t.Fatalf("got %d path matchers, want 2 distinct ones for differing path maps: %+v", len(pathMatchers), pathMatchers)
+	}
+	aRule := hostRuleFor(t, hostRules, "a.com")
+	bRule := hostRuleFor(t, hostRules, "b.com")
+	if aRule.PathMatcher == bRule.PathMatcher {
+		t.Fatalf("expected a.com and b.com to get distinct PathMatchers since their path maps differ, both got %q", aRule.PathMatcher)
+	}
+}
+
+func TestUrlMapRulesForSingleHostSinglePath(t *testing.T) {
+	be := &compute.BackendService{SelfLink: "backend-foo"}
+	gceMap := utils.GCEURLMap{
+		"a.com": {"/foo": be},
+	}
+
+	hostRules, pathMatchers := urlMapRulesFor(gceMap, "default-backend")
+
+	if len(hostRules) != 1 || len(pathMatchers) != 1 {
+		t.Fatalf("got %d host rules and %d path matchers, want 1 and 1: %+v %+v", len(hostRules), len(pathMatchers), hostRules, pathMatchers)
+	}
+	pm := pathMatcherFor(t, pathMatchers, hostRules[0].PathMatcher)
+	if len(pm.PathRules) != 1 || pm.PathRules[0].Paths[0] != "/foo" || pm.PathRules[0].Service != "backend-foo" {
+		t.Fatalf("unexpected path rules: %+v", pm.PathRules)
+	}
+}
+
+func TestUrlMapMatchesIgnoresOrderAndFingerprint(t *testing.T) {
+	pm := func(name string, paths ...string) *compute.PathMatcher {
+		pr := make([]*compute.PathRule, 0, len(paths))
+		for _, p := range paths {
+			pr = append(pr, &compute.PathRule{Paths: []string{p}, Service: "backend-foo"})
+		}
+		return &compute.PathMatcher{Name: name, DefaultService: "default-backend", PathRules: pr}
+	}
+	desired := compute.UrlMap{
+		Name:           "um",
+		DefaultService: "default-backend",
+		Fingerprint:    "fp-1",
+		HostRules: []*compute.HostRule{
+			{Hosts: []string{"b.com"}, PathMatcher: "pm2"},
+			{Hosts: []string{"a.com"}, PathMatcher: "pm1"},
+		},
+		PathMatchers: []*compute.PathMatcher{
+			pm("pm2", "/bar/*", "/bar"),
+			pm("pm1", "/foo"),
+		},
+	}
+	existing := compute.UrlMap{
+		Name:           "um",
+		DefaultService: "default-backend",
+		Fingerprint:    "fp-2",
+		HostRules: []*compute.HostRule{
+			{Hosts: []string{"a.com"}, PathMatcher: "pm1"},
+			{Hosts: []string{"b.com"}, PathMatcher: "pm2"},
+		},
+		PathMatchers: []*compute.PathMatcher{
+			pm("pm1", "/foo"),
+			pm("pm2", "/bar", "/bar/*"),
+		},
+	}
+
+	if !urlMapMatches(desired, existing) {
+		t.Fatalf("expected urlMapMatches to ignore HostRule/PathMatcher/PathRule ordering and Fingerprint")
+	}
+}
+
+func TestPathExpressionsForType(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		pathType networkingv1.PathType
+		want     []string
+		wantErr  bool
+	}{
+		{name: "exact", path: "/foo", pathType: networkingv1.PathTypeExact, want: []string{"/foo"}},
+		{name: "exact empty path errors", path: "", pathType: networkingv1.PathTypeExact, wantErr: true},
+		{name: "prefix", path: "/foo", pathType: networkingv1.PathTypePrefix, want: []string{"/foo", "/foo/*"}},
+		{name: "prefix trailing slash", path: "/foo/", pathType: networkingv1.PathTypePrefix, want: []string{"/foo", "/foo/*"}},
+		{name: "prefix root", path: "/", pathType: networkingv1.PathTypePrefix, want: []string{"/*"}},
+		{name: "implementation specific", path: "/foo", pathType: networkingv1.PathTypeImplementationSpecific, want: []string{"/foo"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := pathExpressionsForType(c.path, c.pathType)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got exprs %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("got %+v, want %+v", got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Fatalf("got %+v, want %+v", got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIngToURLMapConflictingPathTypesKeepsFirstAndReportsError(t *testing.T) {
+	beMap := backendservice.BackendServicesMap{
+		"exact-svc":   {SelfLink: "backend-exact"},
+		"prefix-svc":  {SelfLink: "backend-prefix"},
+		"default-svc": {SelfLink: "backend-default"},
+	}
+	ing := fakeIngress{
+		ns: "default",
+		rs: []commonIngressRule{
+			{
+				host: "a.com",
+				paths: []commonIngressPath{
+					{path: "/foo", pathType: networkingv1.PathTypeExact, backend: &v1beta1.IngressBackend{ServiceName: "exact-svc"}},
+					{path: "/foo", pathType: networkingv1.PathTypePrefix, backend: &v1beta1.IngressBackend{ServiceName: "prefix-svc"}},
+				},
+			},
+		},
+		defBE: &v1beta1.IngressBackend{ServiceName: "default-svc"},
+	}
+
+	s := &Syncer{}
+	gceMap, err := s.ingToURLMap(ing, beMap)
+	if err == nil {
+		t.Fatalf("expected an error reporting the conflicting path types for /foo")
+	}
+
+	pathToBackend := gceMap["a.com"]
+	if pathToBackend["/foo"].SelfLink != "backend-exact" {
+		t.Fatalf("expected the first-seen (Exact) backend to win for the conflicting path, got %+v", pathToBackend["/foo"])
+	}
+	if pathToBackend["/foo/*"].SelfLink != "backend-prefix" {
+		t.Fatalf("expected the non-conflicting /foo/* expansion from the Prefix path to still be recorded, got %+v", pathToBackend["/foo/*"])
+	}
+}
+
+func TestUrlMapMatchesToleratesDuplicatedPrefixPaths(t *testing.T) {
+	// A Prefix path expands to both "/foo" and "/foo/*"; confirm urlMapMatches
+	// still matches two url maps that differ only in the order those
+	// expanded PathRules were appended in.
+	desired := compute.UrlMap{
+		Name:           "um",
+		DefaultService: "default-backend",
+		HostRules:      []*compute.HostRule{{Hosts: []string{"a.com"}, PathMatcher: "pm1"}},
+		PathMatchers: []*compute.PathMatcher{{
+			Name:           "pm1",
+			DefaultService: "default-backend",
+			PathRules: []*compute.PathRule{
+				{Paths: []string{"/foo"}, Service: "backend-foo"},
+				{Paths: []string{"/foo/*"}, Service: "backend-foo"},
+			},
+		}},
+	}
+	existing := compute.UrlMap{
+		Name:           "um",
+		DefaultService: "default-backend",
+		HostRules:      []*compute.HostRule{{Hosts: []string{"a.com"}, PathMatcher: "pm1"}},
+		PathMatchers: []*compute.PathMatcher{{
+			Name:           "pm1",
+			DefaultService: "default-backend",
+			PathRules: []*compute.PathRule{
+				{Paths: []string{"/foo/*"}, Service: "backend-foo"},
+				{Paths: []string{"/foo"}, Service: "backend-foo"},
+			},
+		}},
+	}
+
+	if !urlMapMatches(desired, existing) {
+		t.Fatalf("expected urlMapMatches to tolerate differently-ordered duplicated prefix paths")
+	}
+}